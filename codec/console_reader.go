@@ -1,6 +1,7 @@
 package codec
 
 import (
+	"bufio"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	pbsui "github.com/apocentre/firehose-sui/pb/sf/sui/type/v1"
+	"github.com/prometheus/client_golang/prometheus"
 	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
 	firecore "github.com/streamingfast/firehose-core"
 	"github.com/streamingfast/firehose-core/node-manager/mindreader"
@@ -21,7 +23,7 @@ import (
 // ConsoleReader is what reads the `geth` output directly. It builds
 // up some LogEntry objects. See `LogReader to read those entries .
 type ConsoleReader struct {
-	lines  chan string
+	src    *bufio.Reader
 	close  func()
 	done   chan interface{}
 	logger *zap.Logger
@@ -29,25 +31,37 @@ type ConsoleReader struct {
 
 	activeBlockStartTime time.Time
 	activeBlock          *pbsui.CheckpointData
+	// blkLogger is `logger` derived with the active block's height and the chain id,
+	// so every log line and wrapped error emitted while a block is active carries that
+	// context without each callsite having to inject it by hand. It is reset to `logger`
+	// once the active block completes.
+	blkLogger            *zap.Logger
 	// Chain Identifier is the digest of the genesis checkpoint
 	chainID              string
 	initRead             bool
+	// binaryFraming is turned on when `FIRE INIT` advertises the `bin` capability. When
+	// active, the large payload log lines (CHECKPOINT, TRX, OBJ_CHANGE, EVT, DSP_UPDATE)
+	// are framed as `FIRE <TAG>_B <len>` followed by exactly `<len>` raw proto bytes
+	// instead of being base64-encoded and tokenized as text.
+	binaryFraming        bool
 	stats                *consoleReaderStats
 }
 
 func NewConsoleReader(
-	lines chan string,
+	src io.Reader,
 	blockEncoder firecore.BlockEncoder,
+	metricsRegistry *prometheus.Registry,
 	logger *zap.Logger,
 	tracer logging.Tracer,
 ) (mindreader.ConsolerReader, error) {
 	l := &ConsoleReader{
-		lines:  lines,
-		close:  func() {},
-		encoder: blockEncoder,
-		done:   make(chan interface{}),
-		logger: logger,
-		stats: newConsoleReaderStats(),
+		src:       bufio.NewReader(src),
+		close:     func() {},
+		encoder:   blockEncoder,
+		done:      make(chan interface{}),
+		logger:    logger,
+		blkLogger: logger,
+		stats:     newConsoleReaderStats(metricsRegistry),
 	}
 
 	l.stats.StartPeriodicLogToZap(context.Background(), logger, 30*time.Second)
@@ -88,10 +102,35 @@ const (
 	LogEvent          = "EVT"
 	LogDisplayUpdate  = "DSP_UPDATE"
 	LogBlockEnd       = "BLOCK_END"
+
+	// BinaryFramingCapability is the `FIRE INIT` trailing token that opts a stream into
+	// the binary length-prefixed framing mode (see `tagAndBinaryFlag`).
+	BinaryFramingCapability = "bin"
+	// binaryTagSuffix is appended to a log prefix to mark it as binary-framed, e.g.
+	// `CHECKPOINT_B` instead of `CHECKPOINT`.
+	binaryTagSuffix = "_B"
+
+	// maxFrameLength bounds the `<len>` token of a binary-framed payload. It is sized
+	// well above any legitimate Sui checkpoint/transaction payload so that a corrupted
+	// or malicious length token fails fast with an error instead of driving `readFrame`
+	// into a multi-gigabyte allocation.
+	maxFrameLength = 64 * 1024 * 1024
 )
 
+// next reads log lines (and, once binary framing is active, raw byte frames) off `r.src`
+// until it has accumulated a full block or the underlying reader is exhausted.
 func (r *ConsoleReader) next() (out interface{}, err error) {
-	for line := range r.lines {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			if err == io.EOF {
+				r.logger.Info("console reader source has been closed")
+				return nil, io.EOF
+			}
+
+			return nil, fmt.Errorf("reading line: %w", err)
+		}
+
 		if !strings.HasPrefix(line, LogPrefix) {
 			continue
 		}
@@ -100,47 +139,54 @@ func (r *ConsoleReader) next() (out interface{}, err error) {
 		// for example when exchanging JSON object (although we strongly discourage usage of
 		// JSON, use serialized Protobuf object). If you happen to have spaces in the last element,
 		// refactor the code here to avoid the split and perform the split in the line handler directly
-		// instead.
+		// instead, or opt the stream into the binary framing mode (see `FIRE INIT`'s `bin` capability).
 		tokens := strings.Split(line[len(LogPrefix)+1:], " ")
 		if len(tokens) < 2 {
 			return nil, fmt.Errorf("invalid log line %q, expecting at least two tokens", line)
 		}
 
+		tag, binary := tagAndBinaryFlag(tokens[0])
+
 		if !r.initRead {
-			if tokens[0] == LogInit {
+			if tag == LogInit {
 				if err := r.readInit(tokens[1:]); err != nil {
-					return nil, lineError(line, err)
+					return nil, r.lineError(line, tag, err)
 				}
 			} else {
-				r.logger.Warn("received Firehose log line but we did not see 'FIRE INIT' yet, skipping", zap.String("prefix", tokens[0]))
+				r.logger.Warn("received Firehose log line but we did not see 'FIRE INIT' yet, skipping", zap.String("prefix", tag))
 			}
 
 			continue
 		}
 
+		if binary && !r.binaryFraming {
+			return nil, r.lineError(line, tag, fmt.Errorf("received binary-framed %q tag but stream did not opt into binary framing in FIRE INIT", tag))
+		}
+
 		// Order the case from most occurring line prefix to least occurring
-		switch tokens[0] {
+		switch tag {
 		case LogCheckpoint:
-			err = r.readCheckpointOverview(tokens[1:])
+			err = r.readCheckpointOverview(tokens[1:], binary)
 		case LogTrx:
-			err = r.readTransactionBlock(tokens[1:])
+			err = r.readTransactionBlock(tokens[1:], binary)
 		case LogObjChange:
-			err = r.readTransactionObjectChange(tokens[1:])
+			err = r.readTransactionObjectChange(tokens[1:], binary)
 		case LogEvent:
-			err = r.readEvent(tokens[1:])
+			err = r.readEvent(tokens[1:], binary)
 		case LogDisplayUpdate:
-			err = r.readDisplayUpdate(tokens[1:])
+			err = r.readDisplayUpdate(tokens[1:], binary)
 		case LogBlockStart:
 			err = r.readBlockStart(tokens[1:])
 		case LogBlockEnd:
 			// This end the execution of the reading loop as we have a full block here
 			block, err := r.readBlockEnd(tokens[1:])
 			if err != nil {
-				return nil, lineError(line, err)
+				return nil, r.lineError(line, tag, err)
 			}
 
 			return block, nil
 		case LogInit:
+			r.stats.duplicateInits.Inc()
 			err = fmt.Errorf("received INIT line while one has already been read")
 		default:
 			if r.logger.Core().Enabled(zap.DebugLevel) {
@@ -151,16 +197,61 @@ func (r *ConsoleReader) next() (out interface{}, err error) {
 		}
 
 		if err != nil {
-			return nil, lineError(line, err)
+			return nil, r.lineError(line, tag, err)
 		}
 	}
+}
+
+// tagAndBinaryFlag splits a log prefix like `CHECKPOINT_B` into its base tag (`CHECKPOINT`)
+// and whether it was binary-framed.
+func tagAndBinaryFlag(prefix string) (tag string, binary bool) {
+	if strings.HasSuffix(prefix, binaryTagSuffix) {
+		return strings.TrimSuffix(prefix, binaryTagSuffix), true
+	}
+
+	return prefix, false
+}
+
+// readLine returns the next newline-delimited log line off `r.src`, with the trailing
+// newline (and optional carriage return) stripped.
+func (r *ConsoleReader) readLine() (string, error) {
+	line, err := r.src.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line == "" {
+			return "", io.EOF
+		}
+
+		if err != io.EOF {
+			return "", err
+		}
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readFrame reads exactly `length` raw bytes off `r.src`, used for binary-framed payloads.
+// The producer always terminates a frame with a single newline so that the stream stays
+// byte-alignable by a human tailing it; that newline is consumed here and is not part of
+// the payload.
+func (r *ConsoleReader) readFrame(length uint64) ([]byte, error) {
+	if length > maxFrameLength {
+		return nil, fmt.Errorf("binary frame length %d exceeds maximum allowed length of %d", length, maxFrameLength)
+	}
+
+	out := make([]byte, length)
+	if _, err := io.ReadFull(r.src, out); err != nil {
+		return nil, fmt.Errorf("reading binary frame of length %d: %w", length, err)
+	}
 
-	r.logger.Info("lines channel has been closed")
-	return nil, io.EOF
+	if _, err := r.src.Discard(1); err != nil {
+		return nil, fmt.Errorf("reading binary frame of length %d: discarding trailing newline: %w", length, err)
+	}
+
+	return out, nil
 }
 
 // Format:
-// FIRE INIT <client_name> <client_version> <fork> <firehose_major> <firehose_minor> <chain_id>
+// FIRE INIT <client_name> <client_version> <fork> <firehose_major> <firehose_minor> <chain_id> [<capabilities>]
 func (r *ConsoleReader) readInit(params []string) error {
 	if err := validateVariableChunk(params, 6, 7); err != nil {
 		return fmt.Errorf("invalid log line length: %w", err)
@@ -184,13 +275,16 @@ func (r *ConsoleReader) readInit(params []string) error {
 		return fmt.Errorf("only able to consume firehose format with major version 0, got %d", firehoseMajor)
 	}
 
-	chainIDString := ""
-	if len(params) == 6 {
-		chainIDString = params[5]
-	} else {
-		chainIDString = params[6]
+	// chainIDString always lands at index 5; capabilities is the optional 7th token at
+	// index 6 and is never read as the chain id.
+	chainIDString := params[5]
+	capabilities := ""
+	if len(params) == 7 {
+		capabilities = params[6]
 	}
 
+	r.binaryFraming = capabilities == BinaryFramingCapability
+
 	r.logger.Info("initialized console reader correclty",
 		zap.String("client_name", clientName),
 		zap.String("client_version", clientVersion),
@@ -198,6 +292,7 @@ func (r *ConsoleReader) readInit(params []string) error {
 		zap.Uint64("firehose_major", firehoseMajor),
 		zap.Uint64("firehose_minor", firehoseMinor),
 		zap.String("chain_id", chainIDString),
+		zap.Bool("binary_framing", r.binaryFraming),
 	)
 
 	r.chainID = chainIDString
@@ -219,19 +314,24 @@ func (r *ConsoleReader) readBlockStart(params []string) error {
 	}
 
 	if r.activeBlock != nil {
-		r.logger.Info("received BLOCK_START while one is already active, resetting active block and starting over",
+		r.blkLogger.Info("received BLOCK_START while one is already active, resetting active block and starting over",
 			zap.Uint64("previous_active_block_height", r.activeBlock.Checkpoint.SequenceNumber),
 			zap.Uint64("new_active_block_height", height),
 		)
+		r.stats.blockResets.Inc()
 	}
 
 	r.activeBlockStartTime = time.Now()
 	r.activeBlock = &pbsui.CheckpointData {}
+	r.blkLogger = r.logger.With(zap.Uint64("height", height), zap.String("chain_id", r.chainID))
 
 	return nil
 }
 
-func(r *ConsoleReader) validate(params []string, valueType string) ([]byte, error) {
+// validate reads the payload for a log line carrying a single value, either as a base64
+// text token (`params[0]`) or, when `binary` is true, as a length-prefixed raw frame read
+// directly off `r.src` (`params[0]` is then the frame length instead).
+func(r *ConsoleReader) validate(params []string, valueType string, binary bool) ([]byte, error) {
 	if err := validateChunk(params, 1); err != nil {
 		return nil, fmt.Errorf("invalid log line length: %w", err)
 	}
@@ -240,8 +340,25 @@ func(r *ConsoleReader) validate(params []string, valueType string) ([]byte, erro
 		return nil, fmt.Errorf("no active block in progress when reading %s", valueType)
 	}
 
+	if binary {
+		length, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			r.stats.decodeFailures.WithLabelValues(valueType).Inc()
+			return nil, fmt.Errorf("read %s in block %d: invalid frame length %q: %w", valueType, r.activeBlock.GetFirehoseBlockNumber(), params[0], err)
+		}
+
+		out, err := r.readFrame(length)
+		if err != nil {
+			r.stats.decodeFailures.WithLabelValues(valueType).Inc()
+			return nil, fmt.Errorf("read %s in block %d: %w", valueType, r.activeBlock.GetFirehoseBlockNumber(), err)
+		}
+
+		return out, nil
+	}
+
 	out, err := base64.StdEncoding.DecodeString(params[0])
 	if err != nil {
+		r.stats.decodeFailures.WithLabelValues(valueType).Inc()
 		return nil, fmt.Errorf("read %s in block %d: invalid base64 value: %w", valueType, r.activeBlock.GetFirehoseBlockNumber(), err)
 	}
 
@@ -250,91 +367,111 @@ func(r *ConsoleReader) validate(params []string, valueType string) ([]byte, erro
 
 // Format:
 // FIRE CHECKPOINT <pbsui.Checkpoint>
-func(r * ConsoleReader) readCheckpointOverview(params []string) error {
-	out, err := r.validate(params, "CHECKPOINT")
+// FIRE CHECKPOINT_B <len>
+func(r * ConsoleReader) readCheckpointOverview(params []string, binary bool) error {
+	out, err := r.validate(params, "CHECKPOINT", binary)
 	if err != nil {
 		return err
 	}
 
 	checkpoint := &pbsui.Checkpoint{}
 	if err := proto.Unmarshal(out, checkpoint); err != nil {
+		r.stats.decodeFailures.WithLabelValues("CHECKPOINT").Inc()
 		return fmt.Errorf("read CHECKPOINT in block %d: invalid proto: %w", r.activeBlock.GetFirehoseBlockNumber(), err)
 	}
 
 	r.activeBlock.Checkpoint = checkpoint
 
+	r.blkLogger.Debug("read checkpoint overview", zap.Uint64("sequence_number", checkpoint.SequenceNumber))
+
 	return nil
 }
 
 // Format:
 // FIRE TRX <pbsui.Transaction>
-func (r *ConsoleReader) readTransactionBlock(params []string) error {
-	out, err := r.validate(params, "TRX")
+// FIRE TRX_B <len>
+func (r *ConsoleReader) readTransactionBlock(params []string, binary bool) error {
+	out, err := r.validate(params, "TRX", binary)
 	if err != nil {
 		return err
 	}
 
 	transaction := &pbsui.Transaction{}
 	if err := proto.Unmarshal(out, transaction); err != nil {
+		r.stats.decodeFailures.WithLabelValues("TRX").Inc()
 		return fmt.Errorf("read TRX in block %d: invalid proto: %w", r.activeBlock.GetFirehoseBlockNumber(), err)
 	}
 
 	r.activeBlock.Transactions = append(r.activeBlock.Transactions, transaction)
 
+	r.blkLogger.Debug("read transaction block", zap.Int("transaction_count", len(r.activeBlock.Transactions)))
+
 	return nil
 }
 
 // Format:
 // FIRE OBJ_CHANGE <pbsui.TransactionObjectChange>
-func (r *ConsoleReader) readTransactionObjectChange(params []string) error {
-	out, err := r.validate(params, "OBJ_CHANGE")
+// FIRE OBJ_CHANGE_B <len>
+func (r *ConsoleReader) readTransactionObjectChange(params []string, binary bool) error {
+	out, err := r.validate(params, "OBJ_CHANGE", binary)
 	if err != nil {
 		return err
 	}
 
 	tx_object_change := &pbsui.TransactionObjectChange{}
 	if err := proto.Unmarshal(out, tx_object_change); err != nil {
+		r.stats.decodeFailures.WithLabelValues("OBJ_CHANGE").Inc()
 		return fmt.Errorf("read OBJ_CHANGE in block %d: invalid proto: %w", r.activeBlock.GetFirehoseBlockNumber(), err)
 	}
 
 	r.activeBlock.ObjectChange = tx_object_change
 
+	r.blkLogger.Debug("read transaction object change")
+
 	return nil
 }
 
 // Format:
 // FIRE EVT <pbsui.IndexedEvent>
-func (r *ConsoleReader) readEvent(params []string) error {
-	out, err := r.validate(params, "EVT")
+// FIRE EVT_B <len>
+func (r *ConsoleReader) readEvent(params []string, binary bool) error {
+	out, err := r.validate(params, "EVT", binary)
 	if err != nil {
 		return err
 	}
 
 	event := &pbsui.IndexedEvent{}
 	if err := proto.Unmarshal(out, event); err != nil {
+		r.stats.decodeFailures.WithLabelValues("EVT").Inc()
 		return fmt.Errorf("read EVT in block %d: invalid proto: %w", r.activeBlock.GetFirehoseBlockNumber(), err)
 	}
 
 	r.activeBlock.Events = append(r.activeBlock.Events, event)
 
+	r.blkLogger.Debug("read event", zap.Int("event_count", len(r.activeBlock.Events)))
+
 	return nil
 }
 
 // Format:
 // FIRE EVT <pbsui.StoredDisplay>
-func (r *ConsoleReader) readDisplayUpdate(params []string) error {
-	out, err := r.validate(params, "DSP_UPDATE")
+// FIRE DSP_UPDATE_B <len>
+func (r *ConsoleReader) readDisplayUpdate(params []string, binary bool) error {
+	out, err := r.validate(params, "DSP_UPDATE", binary)
 	if err != nil {
 		return err
 	}
 
 	display_update := &pbsui.StoredDisplay{}
 	if err := proto.Unmarshal(out, display_update); err != nil {
+		r.stats.decodeFailures.WithLabelValues("DSP_UPDATE").Inc()
 		return fmt.Errorf("read DSP_UPDATE in block %d: invalid proto: %w", r.activeBlock.GetFirehoseBlockNumber(), err)
 	}
 
 	r.activeBlock.DisplayUpdates = append(r.activeBlock.DisplayUpdates, display_update)
 
+	r.blkLogger.Debug("read display update", zap.Int("display_update_count", len(r.activeBlock.DisplayUpdates)))
+
 	return nil
 }
 
@@ -355,19 +492,18 @@ func (r *ConsoleReader) readBlockEnd(params []string) (*pbbstream.Block, error)
 	}
 
 	if r.activeBlock.GetFirehoseBlockNumber() != height {
+		r.stats.outOfOrderBlockEnds.Inc()
 		return nil, fmt.Errorf("active block's height %d does not match BLOCK_END received height %d", r.activeBlock.GetFirehoseBlockNumber(), height)
 	}
 
 	if len(r.activeBlock.Transactions) == 0 {
+		r.stats.emptyBlockRejects.Inc()
 		return nil, fmt.Errorf("active block height %d does not contain any transaction", r.activeBlock.GetFirehoseBlockNumber())
 	}
 
-	r.stats.blockRate.Inc()
-	r.stats.transactionRate.IncBy(int64(len(r.activeBlock.Transactions)))
-	r.stats.blockAverageParseTime.AddElapsedTime(r.activeBlockStartTime)
-	r.stats.lastBlock = r.activeBlock.AsRef()
+	r.stats.recordBlockCompleted(r.activeBlock.AsRef(), len(r.activeBlock.Transactions), r.activeBlockStartTime)
 
-	r.logger.Debug("console reader node block",
+	r.blkLogger.Debug("console reader node block",
 		zap.String("id", r.activeBlock.GetFirehoseBlockID()),
 		zap.Uint64("height", r.activeBlock.GetFirehoseBlockNumber()),
 		zap.Time("timestamp", r.activeBlock.GetFirehoseBlockTime()),
@@ -387,6 +523,7 @@ func (r *ConsoleReader) readBlockEnd(params []string) (*pbbstream.Block, error)
 func (r *ConsoleReader) resetActiveBlock() {
 	r.activeBlock = nil
 	r.activeBlockStartTime = time.Time{}
+	r.blkLogger = r.logger
 }
 
 func validateChunk(params []string, count int) error {
@@ -411,6 +548,16 @@ func validateVariableChunk(params []string, counts ...int) error {
 	return fmt.Errorf("%s fields required but found %d", strings.Join(countStrings, " or "), len(params))
 }
 
-func lineError(line string, source error) error {
-	return fmt.Errorf("%w (on line %q)", source, line)
+// lineError wraps a parse error that occurred while reading `line`, embedding the active
+// block's height, the chain id, and the offending token type directly into the returned
+// error so that context survives being propagated up to the caller (e.g. mindreader).
+// It intentionally does not log: `next()`'s caller already logs a failed `ReadBlock`, and
+// logging here too would duplicate that line on every single parse error.
+func (r *ConsoleReader) lineError(line string, tokenType string, source error) error {
+	height := uint64(0)
+	if r.activeBlock != nil {
+		height = r.activeBlock.GetFirehoseBlockNumber()
+	}
+
+	return fmt.Errorf("block %d (chain %s) token %s: %w (on line %q)", height, r.chainID, tokenType, source, line)
 }
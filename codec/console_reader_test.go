@@ -0,0 +1,202 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	pbsui "github.com/apocentre/firehose-sui/pb/sf/sui/type/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
+	firecore "github.com/streamingfast/firehose-core"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// passthroughEncoder is a minimal firecore.BlockEncoder that surfaces the checkpoint's
+// height and id straight onto the resulting *pbbstream.Block, enough for these tests to
+// assert a block round-tripped through the reader.
+type passthroughEncoder struct{}
+
+func (passthroughEncoder) Encode(envelope firecore.BlockEnveloppe) (*pbbstream.Block, error) {
+	block := envelope.Block.(*pbsui.CheckpointData)
+	return &pbbstream.Block{
+		Number: block.GetFirehoseBlockNumber(),
+		Id:     block.GetFirehoseBlockID(),
+	}, nil
+}
+
+func newTestConsoleReader(src []byte) *ConsoleReader {
+	return &ConsoleReader{
+		src:       bufio.NewReader(bytes.NewReader(src)),
+		close:     func() {},
+		encoder:   passthroughEncoder{},
+		done:      make(chan interface{}),
+		logger:    zap.NewNop(),
+		blkLogger: zap.NewNop(),
+		stats:     newConsoleReaderStats(prometheus.NewRegistry()),
+	}
+}
+
+// writeBinaryFrame appends a `FIRE <tag>_B <len>\n<payload>\n` binary frame to `buf`, as
+// produced by an indexer streaming in binary framing mode.
+func writeBinaryFrame(buf *bytes.Buffer, tag string, payload []byte) {
+	fmt.Fprintf(buf, "FIRE %s_B %d\n", tag, len(payload))
+	buf.Write(payload)
+	buf.WriteByte('\n')
+}
+
+func TestConsoleReader_ReadBlock_TextMode(t *testing.T) {
+	checkpoint, err := proto.Marshal(&pbsui.Checkpoint{SequenceNumber: 1})
+	require.NoError(t, err)
+
+	trx, err := proto.Marshal(&pbsui.Transaction{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 1\n")
+	fmt.Fprintf(&buf, "FIRE CHECKPOINT %s\n", base64.StdEncoding.EncodeToString(checkpoint))
+	fmt.Fprintf(&buf, "FIRE TRX %s\n", base64.StdEncoding.EncodeToString(trx))
+	fmt.Fprintf(&buf, "FIRE BLOCK_END 1\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	out, err := r.next()
+	require.NoError(t, err)
+
+	block := out.(*pbbstream.Block)
+	require.Equal(t, uint64(1), block.Number)
+	require.False(t, r.binaryFraming)
+}
+
+func TestConsoleReader_ReadBlock_BinaryMode(t *testing.T) {
+	checkpoint, err := proto.Marshal(&pbsui.Checkpoint{SequenceNumber: 1})
+	require.NoError(t, err)
+
+	trx, err := proto.Marshal(&pbsui.Transaction{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet bin\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 1\n")
+	writeBinaryFrame(&buf, "CHECKPOINT", checkpoint)
+	writeBinaryFrame(&buf, "TRX", trx)
+	fmt.Fprintf(&buf, "FIRE BLOCK_END 1\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	out, err := r.next()
+	require.NoError(t, err)
+	require.True(t, r.binaryFraming)
+
+	block := out.(*pbbstream.Block)
+	require.Equal(t, uint64(1), block.Number)
+}
+
+func TestConsoleReader_ReadInit_CapabilityIsNotTheChainID(t *testing.T) {
+	r := newTestConsoleReader(nil)
+
+	require.NoError(t, r.readInit([]string{"sui-indexer", "1.0.0", "false", "0", "0", "testnet", "bin"}))
+	require.Equal(t, "testnet", r.chainID)
+	require.True(t, r.binaryFraming)
+
+	r = newTestConsoleReader(nil)
+	require.NoError(t, r.readInit([]string{"sui-indexer", "1.0.0", "false", "0", "0", "testnet"}))
+	require.Equal(t, "testnet", r.chainID)
+	require.False(t, r.binaryFraming)
+}
+
+func TestConsoleReader_ReadFrame_RejectsOversizedLength(t *testing.T) {
+	r := newTestConsoleReader(nil)
+
+	_, err := r.readFrame(maxFrameLength + 1)
+	require.Error(t, err)
+}
+
+func TestConsoleReader_Stats_BlockResets(t *testing.T) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 1\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 2\n")
+
+	checkpoint, err := proto.Marshal(&pbsui.Checkpoint{SequenceNumber: 2})
+	require.NoError(t, err)
+	trx, err := proto.Marshal(&pbsui.Transaction{})
+	require.NoError(t, err)
+	fmt.Fprintf(&buf, "FIRE CHECKPOINT %s\n", base64.StdEncoding.EncodeToString(checkpoint))
+	fmt.Fprintf(&buf, "FIRE TRX %s\n", base64.StdEncoding.EncodeToString(trx))
+	fmt.Fprintf(&buf, "FIRE BLOCK_END 2\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	_, err = r.next()
+	require.NoError(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(r.stats.blockResets))
+}
+
+func TestConsoleReader_Stats_EmptyBlockRejects(t *testing.T) {
+	checkpoint, err := proto.Marshal(&pbsui.Checkpoint{SequenceNumber: 1})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 1\n")
+	fmt.Fprintf(&buf, "FIRE CHECKPOINT %s\n", base64.StdEncoding.EncodeToString(checkpoint))
+	fmt.Fprintf(&buf, "FIRE BLOCK_END 1\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	_, err = r.next()
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(r.stats.emptyBlockRejects))
+}
+
+func TestConsoleReader_Stats_OutOfOrderBlockEnds(t *testing.T) {
+	checkpoint, err := proto.Marshal(&pbsui.Checkpoint{SequenceNumber: 1})
+	require.NoError(t, err)
+	trx, err := proto.Marshal(&pbsui.Transaction{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 1\n")
+	fmt.Fprintf(&buf, "FIRE CHECKPOINT %s\n", base64.StdEncoding.EncodeToString(checkpoint))
+	fmt.Fprintf(&buf, "FIRE TRX %s\n", base64.StdEncoding.EncodeToString(trx))
+	fmt.Fprintf(&buf, "FIRE BLOCK_END 2\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	_, err = r.next()
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(r.stats.outOfOrderBlockEnds))
+}
+
+func TestConsoleReader_Stats_DuplicateInits(t *testing.T) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	_, err := r.next()
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(r.stats.duplicateInits))
+}
+
+func TestConsoleReader_Stats_DecodeFailures(t *testing.T) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FIRE INIT sui-indexer 1.0.0 false 0 0 testnet\n")
+	fmt.Fprintf(&buf, "FIRE BLOCK_START 1\n")
+	fmt.Fprintf(&buf, "FIRE CHECKPOINT not-valid-base64!!!\n")
+
+	r := newTestConsoleReader(buf.Bytes())
+
+	_, err := r.next()
+	require.Error(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(r.stats.decodeFailures.WithLabelValues("CHECKPOINT")))
+}
@@ -4,27 +4,89 @@ import (
 	"context"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	pbbstream "github.com/streamingfast/bstream/pb/sf/bstream/v1"
 	"github.com/streamingfast/dmetrics"
 	"go.uber.org/zap"
 )
 
+// consoleReaderStats tracks the console reader's health. `blockRate`, `transactionRate`
+// and `blockAverageParseTime` remain local `dmetrics` counters (that's what the periodic
+// zap summary below reads), but since `dmetrics.RateCounter`/`AvgDurationCounter` aren't
+// themselves `prometheus.Collector`s, each is also mirrored onto the `*prometheus.Registry`
+// passed to newConsoleReaderStats via a `promauto.NewGaugeFunc` that reads the counter's
+// current value at scrape time. Everything else is registered directly against that same
+// registry so it shows up on the process' `/metrics` endpoint. The registry is injected
+// rather than reached for as a package global so that tests can spin up several reader
+// instances, each against its own registry, without collisions.
 type consoleReaderStats struct {
 	lastBlock             pbbstream.BlockRef
 	blockRate             *dmetrics.RateCounter
 	blockAverageParseTime *dmetrics.AvgDurationCounter
 	transactionRate       *dmetrics.RateCounter
 
+	headBlockNumber     prometheus.Gauge
+	blockResets         prometheus.Counter
+	emptyBlockRejects   prometheus.Counter
+	decodeFailures      *prometheus.CounterVec
+	outOfOrderBlockEnds prometheus.Counter
+	duplicateInits      prometheus.Counter
+
 	cancelPeriodicLogger context.CancelFunc
 }
 
-func newConsoleReaderStats() *consoleReaderStats {
-	return &consoleReaderStats{
+func newConsoleReaderStats(reg *prometheus.Registry) *consoleReaderStats {
+	factory := promauto.With(reg)
+
+	s := &consoleReaderStats{
 		lastBlock:             pbbstream.BlockRef{},
 		blockRate:             dmetrics.NewPerSecondLocalRateCounter("blocks"),
 		blockAverageParseTime: dmetrics.NewAvgDurationCounter(5*time.Second, time.Millisecond, "ms/block"),
 		transactionRate:       dmetrics.NewPerSecondLocalRateCounter("trxs"),
 	}
+
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "console_reader_block_rate",
+		Help: "Instantaneous rate, in blocks per second, at which the console reader is reading blocks",
+	}, func() float64 { return s.blockRate.Rate() })
+
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "console_reader_transaction_rate",
+		Help: "Instantaneous rate, in transactions per second, at which the console reader is reading transactions",
+	}, func() float64 { return s.transactionRate.Rate() })
+
+	factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "console_reader_block_average_parse_time_ms",
+		Help: "Rolling average time, in milliseconds, the console reader takes to parse a block",
+	}, func() float64 { return float64(s.blockAverageParseTime.Average().Milliseconds()) })
+
+	s.headBlockNumber = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "console_reader_head_block_number",
+		Help: "Height of the last block fully read by the console reader",
+	})
+	s.blockResets = factory.NewCounter(prometheus.CounterOpts{
+		Name: "console_reader_block_resets",
+		Help: "Number of BLOCK_START received while a block was already active",
+	})
+	s.emptyBlockRejects = factory.NewCounter(prometheus.CounterOpts{
+		Name: "console_reader_empty_block_rejects",
+		Help: "Number of blocks rejected for containing no transaction",
+	})
+	s.decodeFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "console_reader_decode_failures",
+		Help: "Number of base64/binary-frame and proto decode failures, by log token type",
+	}, []string{"token_type"})
+	s.outOfOrderBlockEnds = factory.NewCounter(prometheus.CounterOpts{
+		Name: "console_reader_out_of_order_block_ends",
+		Help: "Number of BLOCK_END received with a height that does not match the active block",
+	})
+	s.duplicateInits = factory.NewCounter(prometheus.CounterOpts{
+		Name: "console_reader_duplicate_inits",
+		Help: "Number of INIT lines received after the stream had already been initialized",
+	})
+
+	return s
 }
 
 func (s *consoleReaderStats) StartPeriodicLogToZap(ctx context.Context, logger *zap.Logger, logEach time.Duration) {
@@ -49,6 +111,16 @@ func (s *consoleReaderStats) StopPeriodicLogToZap() {
 	}
 }
 
+// recordBlockCompleted updates the rate counters, the last-seen block, and the
+// `console_reader_head_block_number` gauge for a block that was just fully read.
+func (s *consoleReaderStats) recordBlockCompleted(ref pbbstream.BlockRef, transactionCount int, startTime time.Time) {
+	s.blockRate.Inc()
+	s.transactionRate.IncBy(int64(transactionCount))
+	s.blockAverageParseTime.AddElapsedTime(startTime)
+	s.lastBlock = ref
+	s.headBlockNumber.Set(float64(ref.Num))
+}
+
 func (s *consoleReaderStats) ZapFields() []zap.Field {
 	return []zap.Field{
 		zap.Stringer("block_rate", s.blockRate),